@@ -0,0 +1,128 @@
+package abac
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Loader loads a JSONL policy file (one Policy object per line) and keeps an
+// in-memory snapshot of it current by watching the file for changes.
+type Loader struct {
+	path     string
+	logger   *slog.Logger
+	policies atomic.Pointer[[]Policy]
+}
+
+// NewLoader reads path once and returns a ready-to-use Loader. Call Watch to
+// keep the in-memory snapshot current as the file changes on disk.
+func NewLoader(path string, logger *slog.Logger) (*Loader, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	l := &Loader{path: path, logger: logger}
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Policies returns the current in-memory snapshot of every loaded policy.
+func (l *Loader) Policies() []Policy {
+	if p := l.policies.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// PoliciesFor returns the policies that apply to user.
+func (l *Loader) PoliciesFor(user Identity) []Policy {
+	all := l.Policies()
+	matched := make([]Policy, 0, len(all))
+	for _, p := range all {
+		if p.matches(user) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// Watch watches the policy file for changes and reloads the in-memory
+// snapshot whenever it is written, atomically swapping it in so readers
+// never observe a partial reload. It blocks until ctx is canceled or the
+// watcher fails to start.
+func (l *Loader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("abac: failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(l.path); err != nil {
+		return fmt.Errorf("abac: failed to watch %s: %w", l.path, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := l.reload(); err != nil {
+				l.logger.Error("failed to reload abac policy file", "path", l.path, "error", err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			l.logger.Error("abac policy file watcher error", "path", l.path, "error", err)
+		}
+	}
+}
+
+// reload parses the policy file and atomically swaps it in as the current
+// snapshot. A malformed line is logged and skipped rather than aborting the
+// whole reload, so one bad edit can't take every policy down.
+func (l *Loader) reload() error {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return fmt.Errorf("abac: failed to open %s: %w", l.path, err)
+	}
+	defer f.Close()
+
+	var policies []Policy
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var p Policy
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			l.logger.Error("skipping invalid abac policy line", "path", l.path, "line", lineNo, "error", err)
+			continue
+		}
+		policies = append(policies, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("abac: failed to read %s: %w", l.path, err)
+	}
+
+	l.policies.Store(&policies)
+	return nil
+}
@@ -0,0 +1,141 @@
+package abac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePolicyFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policies.jsonl")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write policy fixture: %v", err)
+	}
+	return path
+}
+
+func TestLoader_LoadsPolicies(t *testing.T) {
+	path := writePolicyFile(t, `
+{"user":"admin","action":"datasources:read","scope":"datasources:*"}
+
+{"user":"*","orgRole":"Editor","action":"dashboards:write","scope":"dashboards:*","namespace":"team-a"}
+`)
+
+	loader, err := NewLoader(path, nil)
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+
+	if got := len(loader.Policies()); got != 2 {
+		t.Fatalf("Policies() returned %d policies, want 2", got)
+	}
+}
+
+func TestLoader_SkipsInvalidLines(t *testing.T) {
+	path := writePolicyFile(t, `
+{"user":"admin","action":"datasources:read","scope":"datasources:*"}
+not valid json
+{"user":"viewer","action":"dashboards:read","scope":"dashboards:*"}
+`)
+
+	loader, err := NewLoader(path, nil)
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+
+	if got := len(loader.Policies()); got != 2 {
+		t.Fatalf("Policies() returned %d policies, want 2 (the invalid line should be skipped)", got)
+	}
+}
+
+func TestLoader_ReloadPicksUpChanges(t *testing.T) {
+	path := writePolicyFile(t, `{"user":"admin","action":"datasources:read","scope":"datasources:*"}`)
+
+	loader, err := NewLoader(path, nil)
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+	if got := len(loader.Policies()); got != 1 {
+		t.Fatalf("Policies() returned %d policies, want 1", got)
+	}
+
+	const updated = `{"user":"admin","action":"datasources:read","scope":"datasources:*"}
+{"user":"viewer","action":"dashboards:read","scope":"dashboards:*"}`
+	if err := os.WriteFile(path, []byte(updated), 0o600); err != nil {
+		t.Fatalf("failed to update policy fixture: %v", err)
+	}
+
+	if err := loader.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if got := len(loader.Policies()); got != 2 {
+		t.Fatalf("Policies() returned %d policies after reload, want 2", got)
+	}
+}
+
+func TestLoader_Watch(t *testing.T) {
+	path := writePolicyFile(t, `{"user":"admin","action":"datasources:read","scope":"datasources:*"}`)
+
+	loader, err := NewLoader(path, nil)
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+	if got := len(loader.Policies()); got != 1 {
+		t.Fatalf("Policies() returned %d policies, want 1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watchErr := make(chan error, 1)
+	go func() { watchErr <- loader.Watch(ctx) }()
+
+	const updated = `{"user":"admin","action":"datasources:read","scope":"datasources:*"}
+{"user":"viewer","action":"dashboards:read","scope":"dashboards:*"}`
+
+	// Watch only starts watching the file after it's scheduled, so rewrite
+	// the fixture on every tick until the watcher has had a chance to pick
+	// it up, rather than racing a single write against watcher.Add.
+	deadline := time.After(5 * time.Second)
+	for {
+		if got := len(loader.Policies()); got == 2 {
+			break
+		}
+		if err := os.WriteFile(path, []byte(updated), 0o600); err != nil {
+			t.Fatalf("failed to update policy fixture: %v", err)
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("Policies() did not pick up the file change via Watch within the deadline, still have %d policies", len(loader.Policies()))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-watchErr; err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+}
+
+func TestLoader_PoliciesFor(t *testing.T) {
+	path := writePolicyFile(t, `
+{"user":"admin","action":"datasources:read","scope":"datasources:*"}
+{"user":"*","orgRole":"Editor","action":"dashboards:write","scope":"dashboards:*","namespace":"team-a"}
+`)
+
+	loader, err := NewLoader(path, nil)
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+
+	matched := loader.PoliciesFor(Identity{Login: "someone-else", OrgRole: "Editor", Namespace: "team-a"})
+	if len(matched) != 1 {
+		t.Fatalf("PoliciesFor() returned %d policies, want 1", len(matched))
+	}
+	if matched[0].Action != "dashboards:write" {
+		t.Errorf("PoliciesFor() matched action %q, want dashboards:write", matched[0].Action)
+	}
+}
@@ -0,0 +1,60 @@
+// Package abac loads a flat-file, JSONL ABAC policy source and merges it
+// with Grafana's SQL-backed permissions at evaluation time, so operators can
+// manage a GitOps-friendly policy file independently of the permission store.
+package abac
+
+import "strings"
+
+// Policy is a single file-declared permission grant. User, OrgRole, Action,
+// Scope and Namespace may each be "*" (or empty, for User/OrgRole/Namespace)
+// to match anything.
+type Policy struct {
+	User      string `json:"user"`
+	OrgRole   string `json:"orgRole"`
+	Action    string `json:"action"`
+	Scope     string `json:"scope"`
+	Namespace string `json:"namespace"`
+	ReadOnly  bool   `json:"readonly"`
+}
+
+// Identity is the minimal set of attributes abac needs to match a Policy
+// against a request, independent of Grafana's full user model.
+type Identity struct {
+	Login     string
+	OrgRole   string
+	Namespace string
+}
+
+// matches reports whether p applies to user. A readonly policy only grants
+// actions that look like reads (e.g. "datasources:read"), so a GitOps policy
+// file can hand out broad access without also granting writes.
+func (p Policy) matches(user Identity) bool {
+	if !wildcardMatch(p.Namespace, user.Namespace) {
+		return false
+	}
+	if !wildcardMatch(p.User, user.Login) {
+		return false
+	}
+	if !wildcardMatch(p.OrgRole, user.OrgRole) {
+		return false
+	}
+	if p.ReadOnly && !isReadAction(p.Action) {
+		return false
+	}
+	return true
+}
+
+func wildcardMatch(pattern, value string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	return pattern == value
+}
+
+func isReadAction(action string) bool {
+	segment := action
+	if i := strings.LastIndex(action, ":"); i != -1 {
+		segment = action[i+1:]
+	}
+	return segment == "read"
+}
@@ -0,0 +1,63 @@
+package abac
+
+import "testing"
+
+func TestPolicy_Matches(t *testing.T) {
+	tests := []struct {
+		desc     string
+		policy   Policy
+		user     Identity
+		expected bool
+	}{
+		{
+			desc:     "should match an exact user",
+			policy:   Policy{User: "admin", Action: "datasources:read"},
+			user:     Identity{Login: "admin"},
+			expected: true,
+		},
+		{
+			desc:     "should not match a different user",
+			policy:   Policy{User: "admin", Action: "datasources:read"},
+			user:     Identity{Login: "viewer"},
+			expected: false,
+		},
+		{
+			desc:     "should match any user with a wildcard",
+			policy:   Policy{User: "*", OrgRole: "Editor", Action: "dashboards:write"},
+			user:     Identity{Login: "anyone", OrgRole: "Editor"},
+			expected: true,
+		},
+		{
+			desc:     "should not match a different org role",
+			policy:   Policy{OrgRole: "Admin", Action: "dashboards:write"},
+			user:     Identity{Login: "anyone", OrgRole: "Editor"},
+			expected: false,
+		},
+		{
+			desc:     "should not match a different namespace",
+			policy:   Policy{Namespace: "team-a", Action: "dashboards:read"},
+			user:     Identity{Login: "anyone", Namespace: "team-b"},
+			expected: false,
+		},
+		{
+			desc:     "readonly policy should match a read action",
+			policy:   Policy{ReadOnly: true, Action: "dashboards:read"},
+			user:     Identity{Login: "anyone"},
+			expected: true,
+		},
+		{
+			desc:     "readonly policy should not match a write action",
+			policy:   Policy{ReadOnly: true, Action: "dashboards:write"},
+			user:     Identity{Login: "anyone"},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := test.policy.matches(test.user); got != test.expected {
+				t.Errorf("matches() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package accesscontrol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// conditionEnv declares the attribute schema available to CEL conditions:
+//
+//	user.id, user.login, user.orgRole     - the acting user
+//	req.method, req.path, req.ip          - the inbound request
+//	resource.labels                       - labels on the resource being accessed
+//	time.now                              - time of evaluation
+//
+// Conditions are free to index into these maps, e.g.
+// `user.orgRole == "Editor" && resource.labels["env"] != "prod"`.
+var conditionEnv = mustConditionEnv()
+
+func mustConditionEnv() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Variable("user", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("req", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("time", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("accesscontrol: failed to build CEL condition environment: %s", err))
+	}
+	return env
+}
+
+// conditionPrograms caches compiled CEL programs by expression, so that a
+// policy reused across many requests is only parsed and type-checked once.
+var conditionPrograms sync.Map // map[string]cel.Program
+
+func compileCondition(expr string) (cel.Program, error) {
+	if cached, ok := conditionPrograms.Load(expr); ok {
+		return cached.(cel.Program), nil
+	}
+
+	ast, iss := conditionEnv.Compile(expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, fmt.Errorf("accesscontrol: invalid condition %q: %w", expr, iss.Err())
+	}
+
+	prg, err := conditionEnv.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("accesscontrol: failed to build CEL program for %q: %w", expr, err)
+	}
+
+	actual, _ := conditionPrograms.LoadOrStore(expr, prg)
+	return actual.(cel.Program), nil
+}
+
+// EvalCondition returns an evaluator that requires a CEL predicate over the
+// request's attribute environment (see conditionEnv) to evaluate to true. It
+// carries no action or scope of its own, so it is typically combined with
+// EvalPermission via EvalAll to layer ABAC on top of the existing RBAC check,
+// e.g.:
+//
+//	EvalAll(
+//	    EvalPermission("datasources:read", Scope("datasources", "*")),
+//	    EvalCondition(`resource.labels["env"] != "prod"`),
+//	)
+//
+// Because the condition needs request-time attributes it cannot be decided
+// by Evaluate alone; callers that may encounter one must use
+// EvaluateWithContext.
+func EvalCondition(expr string) Evaluator {
+	return conditionEvaluator{Expr: expr}
+}
+
+type conditionEvaluator struct {
+	Expr string `json:"condition"`
+}
+
+// Evaluate always returns false: a condition cannot be decided without the
+// attribute environment that only EvaluateWithContext receives.
+func (c conditionEvaluator) Evaluate(map[string][]string) bool {
+	return false
+}
+
+func (c conditionEvaluator) EvaluateWithContext(_ context.Context, _ map[string][]string, env map[string]any) (bool, error) {
+	prg, err := compileCondition(c.Expr)
+	if err != nil {
+		return false, err
+	}
+
+	out, _, err := prg.Eval(env)
+	if err != nil {
+		return false, fmt.Errorf("accesscontrol: failed to evaluate condition %q: %w", c.Expr, err)
+	}
+
+	match, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("accesscontrol: condition %q did not evaluate to a bool", c.Expr)
+	}
+
+	return match, nil
+}
+
+// EvaluateWithState always returns PermissionPrompt: deciding a condition
+// requires the attribute environment that only EvaluateWithContext receives,
+// so a tri-state caller without one can't be told anything more certain than
+// "ask again with more context".
+func (c conditionEvaluator) EvaluateWithState(context.Context, map[string][]string) PermissionState {
+	return PermissionPrompt
+}
+
+// EvaluateWithSession delegates to Evaluate: a condition carries no MFA
+// requirement of its own, so the session is irrelevant to it.
+func (c conditionEvaluator) EvaluateWithSession(permissions map[string][]string, _ Session) bool {
+	return c.Evaluate(permissions)
+}
+
+// WithMFA is a no-op: a condition has no leaves to attach an MFA requirement
+// to, so it is returned unchanged.
+func (c conditionEvaluator) WithMFA(...string) Evaluator {
+	return c
+}
+
+// MutateScopes is a no-op: conditions have no scopes to inject parameters into.
+func (c conditionEvaluator) MutateScopes(context.Context, ScopeMutator) (Evaluator, error) {
+	return c, nil
+}
+
+func (c conditionEvaluator) String() string {
+	return fmt.Sprintf("condition:%s", c.Expr)
+}
@@ -0,0 +1,111 @@
+package accesscontrol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionEvaluator_EvaluateWithContext(t *testing.T) {
+	tests := []struct {
+		desc     string
+		expr     string
+		env      map[string]any
+		expected bool
+		wantErr  bool
+	}{
+		{
+			desc: "should evaluate to true when the predicate matches",
+			expr: `user.orgRole == "Editor" && resource.labels["env"] != "prod"`,
+			env: map[string]any{
+				"user":     map[string]any{"orgRole": "Editor"},
+				"resource": map[string]any{"labels": map[string]any{"env": "staging"}},
+			},
+			expected: true,
+		},
+		{
+			desc: "should evaluate to false when the predicate does not match",
+			expr: `user.orgRole == "Editor" && resource.labels["env"] != "prod"`,
+			env: map[string]any{
+				"user":     map[string]any{"orgRole": "Editor"},
+				"resource": map[string]any{"labels": map[string]any{"env": "prod"}},
+			},
+			expected: false,
+		},
+		{
+			desc:    "should error for an invalid expression",
+			expr:    `user.orgRole ==`,
+			env:     map[string]any{"user": map[string]any{"orgRole": "Editor"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ok, err := EvalCondition(test.expr).EvaluateWithContext(context.Background(), nil, test.env)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+}
+
+func TestConditionEvaluator_Evaluate(t *testing.T) {
+	// A bare Evaluate has no attribute environment to test the predicate
+	// against, so conditions never pass through it.
+	ok := EvalCondition(`true`).Evaluate(map[string][]string{})
+	assert.False(t, ok)
+}
+
+func TestEvaluatorDTO_UnmarshalJSON_Condition(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    EvaluatorDTO
+		wantErr bool
+	}{
+		{
+			name: "condition eval",
+			data: []byte(`{"condition": "user.orgRole == \"Editor\""}`),
+			want: EvaluatorDTO{
+				Ev: conditionEvaluator{Expr: `user.orgRole == "Editor"`},
+			},
+		},
+		{
+			name: "all eval composed with condition",
+			data: []byte(`{"all": [
+				{"action": "datasources:read", "scopes": ["datasources:*"]},
+				{"condition": "resource.labels[\"env\"] != \"prod\""}
+			]}`),
+			want: EvaluatorDTO{
+				Ev: allEvaluator{allOf: []Evaluator{
+					permissionEvaluator{Action: "datasources:read", Scopes: []string{"datasources:*"}},
+					conditionEvaluator{Expr: `resource.labels["env"] != "prod"`},
+				}},
+			},
+		},
+		{
+			name:    "condition is not a string",
+			data:    []byte(`{"condition": ["user.orgRole == \"Editor\""]}`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := &EvaluatorDTO{}
+			err := ev.UnmarshalJSON(tt.data)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tt.want, *ev)
+		})
+	}
+}
@@ -0,0 +1,721 @@
+package accesscontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errInvalidFormat is returned when an EvaluatorDTO's JSON does not match any
+// of the known evaluator shapes.
+var errInvalidFormat = errors.New("invalid format for permission evaluator")
+
+// Evaluator evaluates permissions that are grouped by action.
+type Evaluator interface {
+	// Evaluate permissions that are grouped by action.
+	Evaluate(permissions map[string][]string) bool
+	// EvaluateWithContext evaluates permissions the same way Evaluate does,
+	// but additionally gives evaluators that need request-time data (such as
+	// a conditionEvaluator) access to ctx and an attribute environment.
+	EvaluateWithContext(ctx context.Context, permissions map[string][]string, env map[string]any) (bool, error)
+	// EvaluateWithState evaluates permissions into a tri-state PermissionState
+	// rather than a bare bool, consulting a PermissionResolver attached to ctx
+	// (via WithPermissionResolver) for any permission the static permission
+	// map leaves undecided. See PermissionState for what each outcome means.
+	EvaluateWithState(ctx context.Context, permissions map[string][]string) PermissionState
+	// EvaluateWithSession evaluates permissions the same way Evaluate does,
+	// but additionally requires that, wherever the evaluator carries an MFA
+	// requirement (see WithMFA), session satisfied at least one of the
+	// required methods during login.
+	EvaluateWithSession(permissions map[string][]string, session Session) bool
+	// WithMFA returns a copy of the evaluator that additionally requires the
+	// session to have satisfied at least one of methods. On a leaf statement
+	// this sets its own requirement; on EvalAll/EvalAny it distributes the
+	// requirement to every descendant leaf, unioned with any requirement the
+	// leaf already carries.
+	WithMFA(methods ...string) Evaluator
+	// MutateScopes executes a ScopeMutator on the evaluator's scopes.
+	MutateScopes(context.Context, ScopeMutator) (Evaluator, error)
+	fmt.Stringer
+}
+
+// RequireMFA wraps eval, requiring that the session authenticating the
+// request satisfied at least one of methods during login. It is a thin,
+// more descriptive alias for eval.WithMFA(methods...).
+func RequireMFA(eval Evaluator, methods ...string) Evaluator {
+	return eval.WithMFA(methods...)
+}
+
+// Session carries the authentication method references (AMR) satisfied
+// during login, so evaluators with an MFA requirement can check it via
+// EvaluateWithSession.
+type Session struct {
+	AMR []string
+}
+
+// mfaSatisfied reports whether amr intersects required (any-of semantics).
+// No requirement is trivially satisfied.
+func mfaSatisfied(required, amr []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+
+	satisfied := make(map[string]struct{}, len(amr))
+	for _, m := range amr {
+		satisfied[m] = struct{}{}
+	}
+
+	for _, m := range required {
+		if _, ok := satisfied[m]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unionMethods returns the set union of a and b, without duplicates.
+func unionMethods(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	union := make([]string, 0, len(a)+len(b))
+	for _, methods := range [][]string{a, b} {
+		for _, m := range methods {
+			if _, ok := seen[m]; ok {
+				continue
+			}
+			seen[m] = struct{}{}
+			union = append(union, m)
+		}
+	}
+	return union
+}
+
+var _ Evaluator = new(permissionEvaluator)
+
+// Effect determines whether a matched permissionEvaluator statement grants
+// or withholds access. An explicit Deny takes precedence over any matching
+// Allow elsewhere in the same evaluator tree, following the Allow/Deny
+// precedence model used by IAM-style policies.
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+// EvalPermission returns an evaluator that requires the given action and, if
+// any scopes are passed, at least one of them to be granted.
+func EvalPermission(action string, scopes ...string) Evaluator {
+	return permissionEvaluator{Action: action, Scopes: scopes}
+}
+
+// EvalDeny returns an evaluator that explicitly denies the given action and
+// scopes. Combined into an EvalAll/EvalAny tree via the other evaluators in
+// this package, a matching Deny overrides any matching Allow in that same
+// tree, even one reached through a different branch.
+func EvalDeny(action string, scopes ...string) Evaluator {
+	return permissionEvaluator{Action: action, Scopes: scopes, Effect: EffectDeny}
+}
+
+type permissionEvaluator struct {
+	Action     string   `json:"action"`
+	Scopes     []string `json:"scopes,omitempty"`
+	Effect     Effect   `json:"effect,omitempty"`
+	MFAMethods []string `json:"mfa_methods,omitempty"`
+}
+
+func (p permissionEvaluator) WithMFA(methods ...string) Evaluator {
+	p.MFAMethods = unionMethods(p.MFAMethods, methods)
+	return p
+}
+
+func (p permissionEvaluator) MutateScopes(ctx context.Context, mutate ScopeMutator) (Evaluator, error) {
+	if len(p.Scopes) == 0 {
+		return p, nil
+	}
+
+	scopes, err := mutate(ctx, p.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return permissionEvaluator{Action: p.Action, Scopes: scopes, Effect: p.Effect, MFAMethods: p.MFAMethods}, nil
+}
+
+// matches reports whether the statement's action and scopes are satisfied by
+// permissions, regardless of its Effect.
+func (p permissionEvaluator) matches(permissions map[string][]string) bool {
+	granted, ok := permissions[p.Action]
+	if !ok {
+		return false
+	}
+
+	if len(p.Scopes) == 0 {
+		return true
+	}
+
+	for _, target := range p.Scopes {
+		for _, scope := range granted {
+			if scopeMatch(scope, target) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p permissionEvaluator) isDeny() bool {
+	return p.Effect == EffectDeny
+}
+
+// Evaluate reports whether the statement grants access. A Deny statement
+// never grants anything on its own; it only has an effect when it appears
+// inside an EvalAll/EvalAny tree, where a match vetoes the whole tree.
+func (p permissionEvaluator) Evaluate(permissions map[string][]string) bool {
+	if p.isDeny() {
+		return false
+	}
+	return p.matches(permissions)
+}
+
+func (p permissionEvaluator) EvaluateWithContext(_ context.Context, permissions map[string][]string, _ map[string]any) (bool, error) {
+	return p.Evaluate(permissions), nil
+}
+
+// EvaluateWithState reports Granted/Denied when the static permission map
+// decides the statement outright, and otherwise consults a
+// PermissionResolver on ctx (falling back to Prompt if none is set). A Deny
+// statement that doesn't match is vacuously Granted, so it doesn't drag down
+// an aggregate it has no opinion on.
+func (p permissionEvaluator) EvaluateWithState(ctx context.Context, permissions map[string][]string) PermissionState {
+	if p.isDeny() {
+		if p.matches(permissions) {
+			return PermissionDenied
+		}
+		return PermissionGranted
+	}
+
+	if _, ok := permissions[p.Action]; !ok {
+		if resolver := permissionResolverFromContext(ctx); resolver != nil {
+			var scope string
+			if len(p.Scopes) > 0 {
+				scope = p.Scopes[0]
+			}
+			return resolver.Resolve(ctx, p.Action, scope)
+		}
+		return PermissionPrompt
+	}
+
+	if p.matches(permissions) {
+		return PermissionGranted
+	}
+
+	return PermissionDenied
+}
+
+// EvaluateWithSession reports whether the statement grants access and, if it
+// carries an MFA requirement, whether session satisfies it.
+func (p permissionEvaluator) EvaluateWithSession(permissions map[string][]string, session Session) bool {
+	if !p.Evaluate(permissions) {
+		return false
+	}
+	return mfaSatisfied(p.MFAMethods, session.AMR)
+}
+
+func (p permissionEvaluator) String() string {
+	if p.isDeny() {
+		return fmt.Sprintf("deny action:%s scopes:%s", p.Action, strings.Join(p.Scopes, ", "))
+	}
+	return fmt.Sprintf("action:%s scopes:%s", p.Action, strings.Join(p.Scopes, ", "))
+}
+
+// EvalAll returns an evaluator that requires every passed evaluator to
+// evaluate to true.
+func EvalAll(allOf ...Evaluator) Evaluator {
+	return allEvaluator{allOf: allOf}
+}
+
+type allEvaluator struct {
+	allOf []Evaluator
+}
+
+func (a allEvaluator) Evaluate(permissions map[string][]string) bool {
+	if anyDenyMatches(a, permissions) {
+		return false
+	}
+
+	for _, e := range a.allOf {
+		if isDenyEvaluator(e) {
+			continue
+		}
+		if !e.Evaluate(permissions) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a allEvaluator) EvaluateWithContext(ctx context.Context, permissions map[string][]string, env map[string]any) (bool, error) {
+	if anyDenyMatches(a, permissions) {
+		return false, nil
+	}
+
+	for _, e := range a.allOf {
+		if isDenyEvaluator(e) {
+			continue
+		}
+		ok, err := e.EvaluateWithContext(ctx, permissions, env)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (a allEvaluator) EvaluateWithState(ctx context.Context, permissions map[string][]string) PermissionState {
+	states := make([]PermissionState, 0, len(a.allOf))
+	for _, e := range a.allOf {
+		states = append(states, e.EvaluateWithState(ctx, permissions))
+	}
+	return aggregateStates(states)
+}
+
+// EvaluateWithSession requires every branch's action/scopes to match (via
+// Evaluate, which also carries deny precedence), then checks the session
+// against the union of every matching branch's MFA requirement in a single
+// pass - a session satisfying branch A's method also covers branch B's
+// requirement, rather than having to satisfy each branch independently.
+func (a allEvaluator) EvaluateWithSession(permissions map[string][]string, session Session) bool {
+	if !a.Evaluate(permissions) {
+		return false
+	}
+
+	var required []string
+	for _, e := range a.allOf {
+		required = unionMethods(required, collectMFAMethods(e))
+	}
+
+	return mfaSatisfied(required, session.AMR)
+}
+
+// WithMFA distributes the requirement to every descendant leaf, unioning it
+// with any requirement that leaf already carries.
+func (a allEvaluator) WithMFA(methods ...string) Evaluator {
+	withMFA := make([]Evaluator, len(a.allOf))
+	for i, e := range a.allOf {
+		withMFA[i] = e.WithMFA(methods...)
+	}
+	return EvalAll(withMFA...)
+}
+
+func (a allEvaluator) MutateScopes(ctx context.Context, mutate ScopeMutator) (Evaluator, error) {
+	mutated := make([]Evaluator, 0, len(a.allOf))
+	for _, e := range a.allOf {
+		m, err := e.MutateScopes(ctx, mutate)
+		if err != nil {
+			return nil, err
+		}
+		mutated = append(mutated, m)
+	}
+	return EvalAll(mutated...), nil
+}
+
+func (a allEvaluator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		All []EvaluatorDTO `json:"all"`
+	}{All: wrapEvaluators(a.allOf)})
+}
+
+func (a allEvaluator) String() string {
+	evalStrings := make([]string, 0, len(a.allOf))
+	for _, e := range a.allOf {
+		evalStrings = append(evalStrings, e.String())
+	}
+	return fmt.Sprintf("all(%s)", strings.Join(evalStrings, ", "))
+}
+
+// EvalAny returns an evaluator that requires at least one of the passed
+// evaluators to evaluate to true.
+func EvalAny(anyOf ...Evaluator) Evaluator {
+	return anyEvaluator{anyOf: anyOf}
+}
+
+type anyEvaluator struct {
+	anyOf []Evaluator
+}
+
+func (a anyEvaluator) Evaluate(permissions map[string][]string) bool {
+	if anyDenyMatches(a, permissions) {
+		return false
+	}
+
+	for _, e := range a.anyOf {
+		if isDenyEvaluator(e) {
+			continue
+		}
+		if e.Evaluate(permissions) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a anyEvaluator) EvaluateWithContext(ctx context.Context, permissions map[string][]string, env map[string]any) (bool, error) {
+	if anyDenyMatches(a, permissions) {
+		return false, nil
+	}
+
+	for _, e := range a.anyOf {
+		if isDenyEvaluator(e) {
+			continue
+		}
+		ok, err := e.EvaluateWithContext(ctx, permissions, env)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (a anyEvaluator) EvaluateWithState(ctx context.Context, permissions map[string][]string) PermissionState {
+	if anyDenyMatches(a, permissions) {
+		return PermissionDenied
+	}
+
+	states := make([]PermissionState, 0, len(a.anyOf))
+	for _, e := range a.anyOf {
+		if isDenyEvaluator(e) {
+			continue
+		}
+		states = append(states, e.EvaluateWithState(ctx, permissions))
+	}
+	return aggregateAnyStates(states)
+}
+
+// EvaluateWithSession mirrors allEvaluator's guard: a matching Deny anywhere
+// in the tree vetoes the whole group before any branch's MFA requirement is
+// even considered, exactly as the plain bool Evaluate does.
+func (a anyEvaluator) EvaluateWithSession(permissions map[string][]string, session Session) bool {
+	if !a.Evaluate(permissions) {
+		return false
+	}
+
+	for _, e := range a.anyOf {
+		if e.EvaluateWithSession(permissions, session) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMFA distributes the requirement to every descendant leaf, unioning it
+// with any requirement that leaf already carries.
+func (a anyEvaluator) WithMFA(methods ...string) Evaluator {
+	withMFA := make([]Evaluator, len(a.anyOf))
+	for i, e := range a.anyOf {
+		withMFA[i] = e.WithMFA(methods...)
+	}
+	return EvalAny(withMFA...)
+}
+
+func (a anyEvaluator) MutateScopes(ctx context.Context, mutate ScopeMutator) (Evaluator, error) {
+	mutated := make([]Evaluator, 0, len(a.anyOf))
+	for _, e := range a.anyOf {
+		m, err := e.MutateScopes(ctx, mutate)
+		if err != nil {
+			return nil, err
+		}
+		mutated = append(mutated, m)
+	}
+	return EvalAny(mutated...), nil
+}
+
+func (a anyEvaluator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Any []EvaluatorDTO `json:"any"`
+	}{Any: wrapEvaluators(a.anyOf)})
+}
+
+func (a anyEvaluator) String() string {
+	evalStrings := make([]string, 0, len(a.anyOf))
+	for _, e := range a.anyOf {
+		evalStrings = append(evalStrings, e.String())
+	}
+	return fmt.Sprintf("any(%s)", strings.Join(evalStrings, ", "))
+}
+
+// Actions returns the distinct actions referenced anywhere in e's tree, in
+// the order first seen. It lets a caller outside this package (such as an
+// external policy provider merging in permissions of its own) learn which
+// actions an evaluator will check without reaching into its unexported leaf
+// types.
+func Actions(e Evaluator) []string {
+	seen := make(map[string]struct{})
+	var actions []string
+	collectActions(e, seen, &actions)
+	return actions
+}
+
+func collectActions(e Evaluator, seen map[string]struct{}, actions *[]string) {
+	switch v := e.(type) {
+	case permissionEvaluator:
+		if _, ok := seen[v.Action]; ok {
+			return
+		}
+		seen[v.Action] = struct{}{}
+		*actions = append(*actions, v.Action)
+	case allEvaluator:
+		for _, child := range v.allOf {
+			collectActions(child, seen, actions)
+		}
+	case anyEvaluator:
+		for _, child := range v.anyOf {
+			collectActions(child, seen, actions)
+		}
+	}
+}
+
+// isDenyEvaluator reports whether e is a leaf Deny statement, so that
+// allEvaluator/anyEvaluator can exclude it from their ordinary Allow
+// requirement and let anyDenyMatches handle it as a veto instead.
+func isDenyEvaluator(e Evaluator) bool {
+	p, ok := e.(permissionEvaluator)
+	return ok && p.isDeny()
+}
+
+// collectMFAMethods returns the union of MFA methods required anywhere in
+// e's tree, ignoring Deny leaves - a Deny statement vetoes access rather
+// than gating it, so it has no MFA requirement of its own.
+func collectMFAMethods(e Evaluator) []string {
+	switch v := e.(type) {
+	case permissionEvaluator:
+		if v.isDeny() {
+			return nil
+		}
+		return v.MFAMethods
+	case allEvaluator:
+		var methods []string
+		for _, child := range v.allOf {
+			methods = unionMethods(methods, collectMFAMethods(child))
+		}
+		return methods
+	case anyEvaluator:
+		var methods []string
+		for _, child := range v.anyOf {
+			methods = unionMethods(methods, collectMFAMethods(child))
+		}
+		return methods
+	}
+	return nil
+}
+
+// anyDenyMatches walks e's whole tree, including nested EvalAll/EvalAny
+// branches, and reports whether any Deny statement in it matches
+// permissions. A matching Deny anywhere in the tree overrides every Allow in
+// that same tree, regardless of which branch reached it.
+func anyDenyMatches(e Evaluator, permissions map[string][]string) bool {
+	switch v := e.(type) {
+	case permissionEvaluator:
+		return v.isDeny() && v.matches(permissions)
+	case allEvaluator:
+		for _, child := range v.allOf {
+			if anyDenyMatches(child, permissions) {
+				return true
+			}
+		}
+	case anyEvaluator:
+		for _, child := range v.anyOf {
+			if anyDenyMatches(child, permissions) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wrapEvaluators wraps each evaluator in an EvaluatorDTO so a slice of them
+// marshals using the same "action"/"any"/"all"/"condition" shape UnmarshalJSON
+// accepts.
+func wrapEvaluators(evaluators []Evaluator) []EvaluatorDTO {
+	dtos := make([]EvaluatorDTO, len(evaluators))
+	for i, e := range evaluators {
+		dtos[i] = EvaluatorDTO{Ev: e}
+	}
+	return dtos
+}
+
+// EvaluatorDTO wraps an Evaluator so it can be marshaled to and from the JSON
+// shape used by the API (permission trees of "any"/"all"/leaf nodes).
+type EvaluatorDTO struct {
+	Ev Evaluator
+}
+
+func (e *EvaluatorDTO) UnmarshalJSON(data []byte) error {
+	var meta map[string]interface{}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return err
+	}
+
+	eval, err := generateEvaluator(meta)
+	if err != nil {
+		return err
+	}
+
+	e.Ev = eval
+	return nil
+}
+
+func (e EvaluatorDTO) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Ev)
+}
+
+func generateEvaluator(meta map[string]interface{}) (Evaluator, error) {
+	if evalListIface, ok := meta["any"]; ok {
+		eval, err := generateCompositeEvaluator(EvalAny, evalListIface)
+		if err != nil {
+			return nil, err
+		}
+		return applyGroupMFA(eval, meta)
+	}
+
+	if evalListIface, ok := meta["all"]; ok {
+		eval, err := generateCompositeEvaluator(EvalAll, evalListIface)
+		if err != nil {
+			return nil, err
+		}
+		return applyGroupMFA(eval, meta)
+	}
+
+	if exprIface, ok := meta["condition"]; ok {
+		expr, ok := exprIface.(string)
+		if !ok {
+			return nil, errInvalidFormat
+		}
+		return EvalCondition(expr), nil
+	}
+
+	return metaToPermissionEvaluator(meta)
+}
+
+func generateCompositeEvaluator(combine func(...Evaluator) Evaluator, evalListIface interface{}) (Evaluator, error) {
+	evalList, ok := evalListIface.([]interface{})
+	if !ok {
+		return nil, errInvalidFormat
+	}
+
+	evaluators := make([]Evaluator, 0, len(evalList))
+	for _, evalIface := range evalList {
+		eval, ok := evalIface.(map[string]interface{})
+		if !ok {
+			return nil, errInvalidFormat
+		}
+
+		e, err := generateEvaluator(eval)
+		if err != nil {
+			return nil, err
+		}
+
+		evaluators = append(evaluators, e)
+	}
+
+	return combine(evaluators...), nil
+}
+
+// applyGroupMFA distributes a group-level "mfa_methods" requirement to every
+// descendant leaf of eval, unioned with any requirement that leaf already
+// carries. Absent a "mfa_methods" key, eval is returned unchanged.
+func applyGroupMFA(eval Evaluator, meta map[string]interface{}) (Evaluator, error) {
+	methods, ok, err := mfaMethodsFromMeta(meta)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return eval, nil
+	}
+	return eval.WithMFA(methods...), nil
+}
+
+// mfaMethodsFromMeta parses a "mfa_methods" array from a leaf or group node.
+// ok is false when the key is absent, so callers can tell "no requirement"
+// apart from "empty requirement".
+func mfaMethodsFromMeta(meta map[string]interface{}) (methods []string, ok bool, err error) {
+	methodsIface, present := meta["mfa_methods"]
+	if !present {
+		return nil, false, nil
+	}
+
+	methodsList, ok := methodsIface.([]interface{})
+	if !ok {
+		return nil, false, errInvalidFormat
+	}
+
+	methods = make([]string, 0, len(methodsList))
+	for _, methodIface := range methodsList {
+		method, ok := methodIface.(string)
+		if !ok {
+			return nil, false, errInvalidFormat
+		}
+		methods = append(methods, method)
+	}
+
+	return methods, true, nil
+}
+
+func metaToPermissionEvaluator(meta map[string]interface{}) (Evaluator, error) {
+	action, ok := meta["action"].(string)
+	if !ok {
+		return nil, errInvalidFormat
+	}
+
+	var scopes []string
+	if scopesIface, ok := meta["scopes"]; ok {
+		scopesList, ok := scopesIface.([]interface{})
+		if !ok {
+			return nil, errInvalidFormat
+		}
+
+		scopes = make([]string, 0, len(scopesList))
+		for _, scopeIface := range scopesList {
+			scope, ok := scopeIface.(string)
+			if !ok {
+				return nil, errInvalidFormat
+			}
+			scopes = append(scopes, scope)
+		}
+	}
+
+	eval := EvalPermission(action, scopes...)
+
+	if effectIface, ok := meta["effect"]; ok {
+		effectStr, ok := effectIface.(string)
+		if !ok {
+			return nil, errInvalidFormat
+		}
+
+		switch Effect(effectStr) {
+		case EffectDeny:
+			eval = EvalDeny(action, scopes...)
+		case EffectAllow:
+			// eval already defaults to the Allow evaluator above
+		default:
+			return nil, errInvalidFormat
+		}
+	}
+
+	if methods, ok, err := mfaMethodsFromMeta(meta); err != nil {
+		return nil, err
+	} else if ok {
+		eval = eval.WithMFA(methods...)
+	}
+
+	return eval, nil
+}
@@ -541,3 +541,142 @@ func TestEvaluatorDTO_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestDeny_Evaluate(t *testing.T) {
+	tests := []evaluateTestCase{
+		{
+			desc: "deny should override a matching wildcard allow",
+			evaluator: EvalAll(
+				EvalPermission("settings:write", Scope("settings", "*")),
+				EvalDeny("settings:write", Scope("settings", "auth.saml", "*")),
+			),
+			permissions: map[string][]string{
+				"settings:write": {"settings:*"},
+			},
+			expected: false,
+		},
+		{
+			desc: "a non-matching deny does not make a wildcard allow match a concrete grant",
+			evaluator: EvalAll(
+				EvalPermission("settings:write", Scope("settings", "*")),
+				EvalDeny("settings:write", Scope("settings", "auth.saml", "*")),
+			),
+			permissions: map[string][]string{
+				"settings:write": {"settings:auth.ldap:enabled"},
+			},
+			expected: false, // a concrete, non-wildcard grant does not cover the allow's "settings:*" target
+		},
+		{
+			desc: "deny inside EvalAny overrides a matching branch",
+			evaluator: EvalAny(
+				EvalPermission("reports:read", Scope("reports", "1")),
+				EvalDeny("reports:read", Scope("reports", "1")),
+			),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: false,
+		},
+		{
+			desc: "deny inside EvalAny that does not match lets the allow branch win",
+			evaluator: EvalAny(
+				EvalPermission("reports:read", Scope("reports", "1")),
+				EvalDeny("reports:read", Scope("reports", "2")),
+			),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: true,
+		},
+		{
+			desc: "deny nested inside an EvalAll branch of an EvalAny overrides the whole tree",
+			evaluator: EvalAny(
+				EvalPermission("reports:read", Scope("reports", "1")),
+				EvalAll(
+					EvalPermission("reports:read", Scope("reports", "1")),
+					EvalDeny("reports:read", Scope("reports", "1")),
+				),
+			),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: false,
+		},
+		{
+			desc:      "a bare deny never grants access on its own",
+			evaluator: EvalDeny("reports:read", Scope("reports", "1")),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ok := test.evaluator.Evaluate(test.permissions)
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+}
+
+func TestEvaluatorDTO_UnmarshalJSON_Effect(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    EvaluatorDTO
+		wantErr bool
+	}{
+		{
+			name: "deny eval",
+			data: []byte(`{"action": "settings:write", "scopes": ["settings:auth.saml:*"], "effect": "deny"}`),
+			want: EvaluatorDTO{
+				Ev: permissionEvaluator{Action: "settings:write", Scopes: []string{"settings:auth.saml:*"}, Effect: EffectDeny},
+			},
+		},
+		{
+			name: "explicit allow eval",
+			data: []byte(`{"action": "settings:write", "effect": "allow"}`),
+			want: EvaluatorDTO{
+				Ev: permissionEvaluator{Action: "settings:write"},
+			},
+		},
+		{
+			name:    "unknown effect",
+			data:    []byte(`{"action": "settings:write", "effect": "forbid"}`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := &EvaluatorDTO{}
+			err := ev.UnmarshalJSON(tt.data)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tt.want, *ev)
+		})
+	}
+}
+
+func TestEvaluatorDTO_MarshalJSON_RoundTrip(t *testing.T) {
+	original := EvaluatorDTO{
+		Ev: EvalAny(
+			EvalAll(
+				EvalPermission("users:read", Scope("users", "*")),
+				EvalDeny("users:read", Scope("users", "id:1")),
+			),
+			EvalPermission("teams:read", Scope("teams", "*")),
+		),
+	}
+
+	data, err := original.MarshalJSON()
+	require.NoError(t, err)
+
+	var roundTripped EvaluatorDTO
+	require.NoError(t, roundTripped.UnmarshalJSON(data))
+	assert.EqualValues(t, original, roundTripped)
+}
@@ -0,0 +1,203 @@
+package accesscontrol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionTestCase struct {
+	desc        string
+	evaluator   Evaluator
+	permissions map[string][]string
+	session     Session
+	expected    bool
+}
+
+func TestEvaluateWithSession(t *testing.T) {
+	tests := []sessionTestCase{
+		{
+			desc:      "should pass when no MFA method is required",
+			evaluator: EvalPermission("reports:read", "reports:1"),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			session:  Session{},
+			expected: true,
+		},
+		{
+			desc:      "should deny when the session is missing every required method",
+			evaluator: EvalPermission("admin:users:delete").WithMFA("totp", "webauthn"),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"password"}},
+			expected: false,
+		},
+		{
+			desc:      "should pass when the session satisfies any one required method",
+			evaluator: EvalPermission("admin:users:delete").WithMFA("totp", "webauthn"),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"password", "webauthn"}},
+			expected: true,
+		},
+		{
+			desc: "EvalAll should union requirements split across branches",
+			evaluator: EvalAll(
+				EvalPermission("admin:users:delete").WithMFA("totp"),
+				EvalPermission("admin:users:delete").WithMFA("webauthn"),
+			),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"webauthn"}},
+			expected: true,
+		},
+		{
+			desc: "EvalAll should deny when the session satisfies none of the union of branch requirements",
+			evaluator: EvalAll(
+				EvalPermission("admin:users:delete").WithMFA("totp"),
+				EvalPermission("admin:users:delete").WithMFA("webauthn"),
+			),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"password"}},
+			expected: false,
+		},
+		{
+			desc: "EvalAll should deny when a branch's action/scope doesn't match, regardless of MFA",
+			evaluator: EvalAll(
+				EvalPermission("admin:users:delete").WithMFA("totp"),
+				EvalPermission("admin:users:impersonate"),
+			),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"totp"}},
+			expected: false,
+		},
+		{
+			desc: "EvalAny should pick the branch whose MFA requirement the session satisfies",
+			evaluator: EvalAny(
+				EvalPermission("admin:users:delete").WithMFA("totp"),
+				EvalPermission("admin:users:delete").WithMFA("webauthn"),
+			),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"webauthn"}},
+			expected: true,
+		},
+		{
+			desc: "EvalAny should deny when neither branch's requirement is met",
+			evaluator: EvalAny(
+				EvalPermission("admin:users:delete").WithMFA("totp"),
+				EvalPermission("admin:users:delete").WithMFA("webauthn"),
+			),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"password"}},
+			expected: false,
+		},
+		{
+			desc:      "RequireMFA distributes a group-level requirement to a nested tree",
+			evaluator: RequireMFA(EvalAny(EvalPermission("admin:users:delete")), "totp", "webauthn"),
+			permissions: map[string][]string{
+				"admin:users:delete": {},
+			},
+			session:  Session{AMR: []string{"totp"}},
+			expected: true,
+		},
+		{
+			desc: "EvalAny should deny when a matching Deny elsewhere in the tree vetoes it",
+			evaluator: EvalAny(
+				EvalDeny("reports:read", "reports:1"),
+				EvalPermission("reports:read", "reports:1"),
+			),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			session:  Session{},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ok := test.evaluator.EvaluateWithSession(test.permissions, test.session)
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+}
+
+// MutateScopes must carry every field that isn't the scopes themselves -
+// including an MFA requirement - through to the rebuilt evaluator, since it
+// runs on every real request (via ScopeInjector) before evaluation.
+func TestPermissionEvaluator_MutateScopes_PreservesMFA(t *testing.T) {
+	eval := EvalPermission("datasources:delete", Scope("datasources", Parameter(":id"))).WithMFA("totp")
+
+	mutated, err := eval.MutateScopes(context.Background(), ScopeInjector(ScopeParams{URLParams: map[string]string{":id": "1"}}))
+	require.NoError(t, err)
+
+	permissions := map[string][]string{
+		"datasources:delete": {"datasources:1"},
+	}
+
+	assert.True(t, mutated.Evaluate(permissions))
+	assert.False(t, mutated.EvaluateWithSession(permissions, Session{}))
+	assert.True(t, mutated.EvaluateWithSession(permissions, Session{AMR: []string{"totp"}}))
+}
+
+func TestEvaluatorDTO_UnmarshalJSON_MFAMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    EvaluatorDTO
+		wantErr bool
+	}{
+		{
+			name: "leaf-level mfa_methods",
+			data: []byte(`{"action": "admin:users:delete", "mfa_methods": ["totp", "webauthn"]}`),
+			want: EvaluatorDTO{
+				Ev: permissionEvaluator{Action: "admin:users:delete", MFAMethods: []string{"totp", "webauthn"}},
+			},
+		},
+		{
+			name: "group-level mfa_methods distribute to every leaf",
+			data: []byte(`{"all": [
+				{"action": "admin:users:delete"},
+				{"action": "admin:users:read", "mfa_methods": ["totp"]}
+			], "mfa_methods": ["webauthn"]}`),
+			want: EvaluatorDTO{
+				Ev: allEvaluator{allOf: []Evaluator{
+					permissionEvaluator{Action: "admin:users:delete", MFAMethods: []string{"webauthn"}},
+					permissionEvaluator{Action: "admin:users:read", MFAMethods: []string{"totp", "webauthn"}},
+				}},
+			},
+		},
+		{
+			name:    "mfa_methods is not a list",
+			data:    []byte(`{"action": "admin:users:delete", "mfa_methods": "totp"}`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := &EvaluatorDTO{}
+			err := ev.UnmarshalJSON(tt.data)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tt.want, *ev)
+		})
+	}
+}
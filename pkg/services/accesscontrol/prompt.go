@@ -0,0 +1,127 @@
+package accesscontrol
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// PermissionState is the tri-state result of resolving a single permission:
+// explicitly Granted, explicitly Denied, or neither of those (Prompt). A
+// Prompt result means the static permission map (and any PermissionResolver
+// consulted alongside it) could not decide the permission one way or the
+// other, so the caller should surface a challenge instead of an immediate
+// 403 - re-authentication, an MFA step-up, or an admin-approval workflow.
+type PermissionState string
+
+const (
+	PermissionGranted PermissionState = "granted"
+	PermissionDenied  PermissionState = "denied"
+	PermissionPrompt  PermissionState = "prompt"
+)
+
+// PermissionResolver decides the PermissionState of an action/scope pair
+// that permissions neither grants nor denies outright, e.g. by calling out
+// to a step-up auth service or an approval queue.
+type PermissionResolver interface {
+	Resolve(ctx context.Context, action, scope string) PermissionState
+}
+
+type permissionResolverCtxKey struct{}
+
+// WithPermissionResolver returns a context carrying resolver, so that
+// Evaluator.EvaluateWithState can consult it for permissions the static
+// permission map leaves undecided.
+func WithPermissionResolver(ctx context.Context, resolver PermissionResolver) context.Context {
+	return context.WithValue(ctx, permissionResolverCtxKey{}, resolver)
+}
+
+func permissionResolverFromContext(ctx context.Context) PermissionResolver {
+	resolver, _ := ctx.Value(permissionResolverCtxKey{}).(PermissionResolver)
+	return resolver
+}
+
+// aggregateStates combines the tri-state results of an EvalAll group's
+// children: an explicit Denied anywhere wins outright, a full set of
+// Granted results passes, and anything short of that - at least one
+// undecided Prompt - defers to a challenge rather than guessing.
+func aggregateStates(states []PermissionState) PermissionState {
+	sawPrompt := false
+	for _, s := range states {
+		if s == PermissionDenied {
+			return PermissionDenied
+		}
+		if s == PermissionPrompt {
+			sawPrompt = true
+		}
+	}
+
+	if sawPrompt {
+		return PermissionPrompt
+	}
+
+	return PermissionGranted
+}
+
+// aggregateAnyStates combines the tri-state results of an EvalAny group's
+// children, mirroring the first-true-branch semantics of its bool Evaluate:
+// any branch Granted wins outright, an undecided Prompt defers to a
+// challenge only if no branch already grants, and a group where every
+// branch fell through is Denied.
+func aggregateAnyStates(states []PermissionState) PermissionState {
+	sawPrompt := false
+	for _, s := range states {
+		if s == PermissionGranted {
+			return PermissionGranted
+		}
+		if s == PermissionPrompt {
+			sawPrompt = true
+		}
+	}
+
+	if sawPrompt {
+		return PermissionPrompt
+	}
+
+	return PermissionDenied
+}
+
+// ChallengeHandler responds to a PermissionPrompt result for an action
+// matching its registered prefix, e.g. triggering re-authentication, an MFA
+// step-up, or an admin-approval webhook.
+type ChallengeHandler func(ctx context.Context, action, scope string) error
+
+var (
+	challengeHandlersMu sync.RWMutex
+	challengeHandlers   = map[string]ChallengeHandler{}
+)
+
+// RegisterChallengeHandler registers handler for actions starting with
+// prefix, e.g. "admin:" triggering an MFA step-up or "datasources:delete"
+// triggering an approval webhook. ChallengeHandlerFor looks the handler up;
+// EvaluateWithState does not run it. Registering the same prefix twice
+// replaces the previous handler.
+func RegisterChallengeHandler(prefix string, handler ChallengeHandler) {
+	challengeHandlersMu.Lock()
+	defer challengeHandlersMu.Unlock()
+	challengeHandlers[prefix] = handler
+}
+
+// ChallengeHandlerFor returns the handler registered for the longest prefix
+// matching action, or nil if no prefix matches.
+func ChallengeHandlerFor(action string) ChallengeHandler {
+	challengeHandlersMu.RLock()
+	defer challengeHandlersMu.RUnlock()
+
+	var bestPrefix string
+	var bestHandler ChallengeHandler
+	for prefix, handler := range challengeHandlers {
+		if len(prefix) < len(bestPrefix) || !strings.HasPrefix(action, prefix) {
+			continue
+		}
+		bestPrefix = prefix
+		bestHandler = handler
+	}
+
+	return bestHandler
+}
@@ -0,0 +1,171 @@
+package accesscontrol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stateTestCase struct {
+	desc        string
+	evaluator   Evaluator
+	permissions map[string][]string
+	resolver    PermissionResolver
+	expected    PermissionState
+}
+
+type resolverFunc func(ctx context.Context, action, scope string) PermissionState
+
+func (f resolverFunc) Resolve(ctx context.Context, action, scope string) PermissionState {
+	return f(ctx, action, scope)
+}
+
+func TestEvaluateWithState(t *testing.T) {
+	tests := []stateTestCase{
+		{
+			desc:      "should be granted when the permission matches",
+			evaluator: EvalPermission("reports:read", "reports:1"),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: PermissionGranted,
+		},
+		{
+			desc:      "should be denied when the action is known but the scope doesn't match",
+			evaluator: EvalPermission("reports:read", "reports:1"),
+			permissions: map[string][]string{
+				"reports:read": {"reports:2"},
+			},
+			expected: PermissionDenied,
+		},
+		{
+			desc:        "should prompt when the action is unknown and no resolver is set",
+			evaluator:   EvalPermission("admin:impersonate"),
+			permissions: map[string][]string{},
+			expected:    PermissionPrompt,
+		},
+		{
+			desc:        "should consult the resolver when the action is unknown",
+			evaluator:   EvalPermission("admin:impersonate"),
+			permissions: map[string][]string{},
+			resolver: resolverFunc(func(_ context.Context, action, _ string) PermissionState {
+				if action == "admin:impersonate" {
+					return PermissionDenied
+				}
+				return PermissionPrompt
+			}),
+			expected: PermissionDenied,
+		},
+		{
+			desc: "EvalAll should be granted only when every branch is granted",
+			evaluator: EvalAll(
+				EvalPermission("reports:read", "reports:1"),
+				EvalPermission("reports:export", "reports:1"),
+			),
+			permissions: map[string][]string{
+				"reports:read":   {"reports:1"},
+				"reports:export": {"reports:1"},
+			},
+			expected: PermissionGranted,
+		},
+		{
+			desc: "EvalAll should prompt when one branch is undecided",
+			evaluator: EvalAll(
+				EvalPermission("reports:read", "reports:1"),
+				EvalPermission("admin:impersonate"),
+			),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: PermissionPrompt,
+		},
+		{
+			desc: "an explicit deny anywhere in the tree wins over a prompt",
+			evaluator: EvalAll(
+				EvalDeny("reports:read", "reports:1"),
+				EvalPermission("admin:impersonate"),
+			),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: PermissionDenied,
+		},
+		{
+			desc: "EvalAny should be granted when any branch is granted, even if another is undecided",
+			evaluator: EvalAny(
+				EvalPermission("reports:read", "reports:1"),
+				EvalPermission("admin:impersonate"),
+			),
+			permissions: map[string][]string{
+				"reports:read": {"reports:1"},
+			},
+			expected: PermissionGranted,
+		},
+		{
+			desc: "EvalAny should prompt when no branch is granted but one is undecided",
+			evaluator: EvalAny(
+				EvalPermission("reports:read", "reports:1"),
+				EvalPermission("admin:impersonate"),
+			),
+			permissions: map[string][]string{},
+			expected:    PermissionPrompt,
+		},
+		{
+			desc: "EvalAny should be denied when every branch falls through",
+			evaluator: EvalAny(
+				EvalPermission("reports:read", "reports:1"),
+				EvalPermission("reports:export", "reports:1"),
+			),
+			permissions: map[string][]string{
+				"reports:read":   {"reports:2"},
+				"reports:export": {"reports:2"},
+			},
+			expected: PermissionDenied,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if test.resolver != nil {
+				ctx = WithPermissionResolver(ctx, test.resolver)
+			}
+
+			state := test.evaluator.EvaluateWithState(ctx, test.permissions)
+			assert.Equal(t, test.expected, state)
+		})
+	}
+}
+
+func TestChallengeHandlerFor(t *testing.T) {
+	called := ""
+	RegisterChallengeHandler("admin:", func(_ context.Context, action, _ string) error {
+		called = action
+		return nil
+	})
+	RegisterChallengeHandler("datasources:delete", func(_ context.Context, action, _ string) error {
+		called = action
+		return nil
+	})
+
+	t.Run("matches the most specific prefix", func(t *testing.T) {
+		handler := ChallengeHandlerFor("datasources:delete")
+		assert.NotNil(t, handler)
+		require.NoError(t, handler(context.Background(), "datasources:delete", ""))
+		assert.Equal(t, "datasources:delete", called)
+	})
+
+	t.Run("falls back to a broader prefix", func(t *testing.T) {
+		handler := ChallengeHandlerFor("admin:users:delete")
+		assert.NotNil(t, handler)
+		require.NoError(t, handler(context.Background(), "admin:users:delete", ""))
+		assert.Equal(t, "admin:users:delete", called)
+	})
+
+	t.Run("returns nil when nothing matches", func(t *testing.T) {
+		assert.Nil(t, ChallengeHandlerFor("teams:read"))
+	})
+}
+
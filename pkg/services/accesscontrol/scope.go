@@ -0,0 +1,76 @@
+package accesscontrol
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"text/template"
+)
+
+// ScopeMutator alters a list of scopes, for example by injecting request-time
+// parameters into scope templates. It is applied via Evaluator.MutateScopes.
+type ScopeMutator func(context.Context, []string) ([]string, error)
+
+// ScopeParams holds the request-time values used to resolve scope templates
+// produced by Field and Parameter into concrete scopes.
+type ScopeParams struct {
+	OrgID     int64
+	URLParams map[string]string
+}
+
+// Scope builds a scope by joining its parts with ":", e.g.
+// Scope("settings", "auth.saml", "*") -> "settings:auth.saml:*".
+func Scope(parts ...string) string {
+	return strings.Join(parts, ":")
+}
+
+// Field returns a scope template that is resolved against the named field of
+// ScopeParams by ScopeInjector, e.g. Field("OrgID") -> "{{ .OrgID }}".
+func Field(name string) string {
+	return "{{ ." + name + " }}"
+}
+
+// Parameter returns a scope template that is resolved against the named URL
+// parameter of ScopeParams by ScopeInjector, e.g. Parameter(":id") resolves
+// the value bound to ":id" on the incoming request.
+func Parameter(key string) string {
+	return `{{ index .URLParams "` + key + `" }}`
+}
+
+// ScopeInjector returns a ScopeMutator that renders each scope as a Go
+// template against params, filling in any Field or Parameter placeholders.
+func ScopeInjector(params ScopeParams) ScopeMutator {
+	return func(_ context.Context, scopes []string) ([]string, error) {
+		injected := make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			tmpl, err := template.New("scope").Parse(scope)
+			if err != nil {
+				return nil, err
+			}
+
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, params); err != nil {
+				return nil, err
+			}
+
+			injected = append(injected, buf.String())
+		}
+
+		return injected, nil
+	}
+}
+
+// scopeMatch reports whether the granted scope covers the target scope.
+// A granted scope ending in "*" matches any target sharing its prefix;
+// otherwise the two scopes must match exactly.
+func scopeMatch(granted, target string) bool {
+	if granted == target {
+		return true
+	}
+
+	if strings.HasSuffix(granted, "*") {
+		return strings.HasPrefix(target, strings.TrimSuffix(granted, "*"))
+	}
+
+	return false
+}